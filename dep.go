@@ -23,6 +23,7 @@ type Godeps struct {
 	Deps       []Dependency
 
 	outerRoot string
+	path      string // source file, if read via ReadGodeps
 }
 
 // A Dependency is a specific revision of a package.
@@ -30,6 +31,7 @@ type Dependency struct {
 	ImportPath string
 	Comment    string `json:",omitempty"` // Description of commit, if present.
 	Rev        string // VCS-specific commit ID.
+	Hash       string `json:",omitempty"` // SHA-256 of the checked-out tree, if known.
 
 	outerRoot string // dir, if present, in outer GOPATH
 	repoRoot  *vcs.RepoRoot
@@ -84,10 +86,17 @@ func LoadGodeps(a []*Package) (*Godeps, error) {
 				continue
 			}
 			comment := vcs.describe(pkg.Dir, id)
+			hash, err := hashTree(pkg.Dir)
+			if err != nil {
+				log.Println(err)
+				err1 = errors.New("error loading dependencies")
+				continue
+			}
 			g.Deps = append(g.Deps, Dependency{
 				ImportPath: name,
 				Rev:        id,
 				Comment:    comment,
+				Hash:       hash,
 			})
 		}
 	}
@@ -107,6 +116,7 @@ func ReadGodeps(path string) (*Godeps, error) {
 	if err != nil {
 		return nil, err
 	}
+	g.path = path
 	err = g.loadGoList()
 	if err != nil {
 		return nil, err
@@ -146,6 +156,22 @@ func (g *Godeps) WriteTo(w io.Writer) (int, error) {
 	return w.Write(append(b, '\n'))
 }
 
+// Save writes g back to the file it was read from via ReadGodeps,
+// such as to persist a Hash that verifyHash filled in during
+// checkout. It is a no-op error if g didn't come from ReadGodeps.
+func (g *Godeps) Save() error {
+	if g.path == "" {
+		return fmt.Errorf("godeps: Save: no source path (not read via ReadGodeps)")
+	}
+	f, err := os.Create(g.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = g.WriteTo(f)
+	return err
+}
+
 // Returns a path to the local copy of d's repository.
 // E.g.
 //
@@ -203,7 +229,7 @@ func (d Dependency) link(remote, url string) error {
 	return d.vcs.link(d.RepoPath(), remote, url)
 }
 
-func (d Dependency) fetchAndCheckout(remote string) error {
+func (d *Dependency) fetchAndCheckout(remote string) error {
 	if err := d.fetch(remote); err != nil {
 		return fmt.Errorf("fetch: %s", err)
 	}
@@ -217,10 +243,10 @@ func (d Dependency) fetch(remote string) error {
 	return d.vcs.fetch(d.RepoPath(), remote)
 }
 
-func (d Dependency) checkout() error {
+func (d *Dependency) checkout() error {
 	dir := d.WorkdirRoot()
 	if exists(dir) {
-		return nil
+		return d.verifyHash(d.Workdir())
 	}
 	if !d.vcs.exists(d.RepoPath(), d.Rev) {
 		return fmt.Errorf("unknown rev %s for %s", d.Rev, d.ImportPath)
@@ -228,7 +254,32 @@ func (d Dependency) checkout() error {
 	if err := os.MkdirAll(dir, 0777); err != nil {
 		return err
 	}
-	return d.vcs.checkout(dir, d.Rev, d.RepoPath())
+	if err := d.vcs.checkout(dir, d.Rev, d.RepoPath()); err != nil {
+		return err
+	}
+	return d.verifyHash(d.Workdir())
+}
+
+// verifyHash checks the tree checked out at dir against d.Hash, the
+// hash recorded in Godeps.json. If d.Hash is empty (as in
+// Godeps.json files written before hashing existed), it is filled in
+// instead of verified, so that Save (which Restore calls) persists it. This
+// keeps the format backward compatible with older Godeps.json files
+// while closing the gap where a rewritten upstream tag or branch
+// could silently swap in different code at the same Rev.
+func (d *Dependency) verifyHash(dir string) error {
+	sum, err := hashTree(dir)
+	if err != nil {
+		return fmt.Errorf("hashing %s: %s", d.ImportPath, err)
+	}
+	if d.Hash == "" {
+		d.Hash = sum
+		return nil
+	}
+	if sum != d.Hash {
+		return fmt.Errorf("hash mismatch for %s at %s: have %s, want %s", d.ImportPath, d.Rev, sum, d.Hash)
+	}
+	return nil
 }
 
 func pathPrefixIn(a []string, s string) bool {
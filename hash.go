@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// vcsMetaDirs lists the per-VCS metadata directories excluded when
+// hashing a checked-out tree, so that hashes are stable across
+// clones of the same revision made with different remotes or
+// fetch histories.
+var vcsMetaDirs = map[string]bool{
+	".git":    true,
+	".hg":     true,
+	".bzr":    true,
+	".svn":    true,
+	"_darcs":  true,
+	".fossil": true,
+}
+
+// hashTree computes a SHA-256 hash over the contents of dir,
+// excluding VCS metadata directories, in a form stable regardless of
+// the order the filesystem returns entries in. It mirrors the
+// approach Go's own module system uses for its "h1:" hashes: hash
+// each file's contents keyed by its path, then hash the sorted list
+// of per-file digests.
+func hashTree(dir string) (string, error) {
+	var entries []string
+	digests := make(map[string]string)
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if vcsMetaDirs[info.Name()] {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil // e.g. the .git file of a git worktree
+		}
+		if info.IsDir() {
+			return nil
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		entries = append(entries, rel)
+		digests[rel] = sum
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(entries)
+	h := sha256.New()
+	for _, rel := range entries {
+		io.WriteString(h, digests[rel])
+		io.WriteString(h, "  ")
+		io.WriteString(h, rel)
+		io.WriteString(h, "\n")
+	}
+	return "h1:" + strings.ToUpper(hex.EncodeToString(h.Sum(nil))), nil
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
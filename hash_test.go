@@ -0,0 +1,70 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHashTreeStableAcrossOrderAndMetadata(t *testing.T) {
+	dir1 := mustTree(t, map[string]string{
+		"a.go":        "package a\n",
+		"sub/b.go":    "package b\n",
+		".git/HEAD":   "ref: refs/heads/master\n",
+		".git/refs/x": "deadbeef\n",
+	})
+	dir2 := mustTree(t, map[string]string{
+		"sub/b.go":  "package b\n",
+		"a.go":      "package a\n",
+		".git/HEAD": "something else entirely\n",
+	})
+
+	h1, err := hashTree(dir1)
+	if err != nil {
+		t.Fatalf("hashTree(dir1): %s", err)
+	}
+	h2, err := hashTree(dir2)
+	if err != nil {
+		t.Fatalf("hashTree(dir2): %s", err)
+	}
+	if h1 != h2 {
+		t.Errorf("hashTree differed despite identical non-VCS content: %s vs %s", h1, h2)
+	}
+}
+
+func TestHashTreeDetectsContentChange(t *testing.T) {
+	dir1 := mustTree(t, map[string]string{"a.go": "package a\n"})
+	dir2 := mustTree(t, map[string]string{"a.go": "package a // changed\n"})
+
+	h1, err := hashTree(dir1)
+	if err != nil {
+		t.Fatalf("hashTree(dir1): %s", err)
+	}
+	h2, err := hashTree(dir2)
+	if err != nil {
+		t.Fatalf("hashTree(dir2): %s", err)
+	}
+	if h1 == h2 {
+		t.Errorf("hashTree did not change despite different content")
+	}
+}
+
+func mustTree(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "godep-hash-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	for rel, content := range files {
+		p := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
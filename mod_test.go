@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPseudoVersion(t *testing.T) {
+	rev := "abcdef0123456789"
+	tm := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	got := pseudoVersion(rev, tm)
+	want := "v0.0.0-20210304050607-abcdef012345"
+	if got != want {
+		t.Errorf("pseudoVersion(%q, %v) = %q, want %q", rev, tm, got, want)
+	}
+}
+
+func TestPseudoVersionShortRev(t *testing.T) {
+	got := pseudoVersion("abc", time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC))
+	want := "v0.0.0-20210304050607-abc"
+	if got != want {
+		t.Errorf("pseudoVersion with short rev = %q, want %q", got, want)
+	}
+}
+
+func TestWriteGoModIncludesGoDirective(t *testing.T) {
+	requires := []modRequire{
+		{root: "github.com/a/b", version: "v0.0.0-20210101000000-aaaaaaaaaaaa"},
+	}
+	var buf bytes.Buffer
+	if err := writeGoMod(&buf, "example.com/m", "go version go1.21.3 linux/amd64", requires, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `module example.com/m
+
+go 1.21.3
+
+require (
+	github.com/a/b v0.0.0-20210101000000-aaaaaaaaaaaa
+)
+`
+	if buf.String() != want {
+		t.Errorf("writeGoMod =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteGoModWithReplaces(t *testing.T) {
+	requires := []modRequire{{root: "github.com/a/b", version: "v0.0.0-20210101000000-aaaaaaaaaaaa"}}
+	replaces := []modReplace{{root: "github.com/a/b", dir: "../b"}}
+	var buf bytes.Buffer
+	if err := writeGoMod(&buf, "example.com/m", "go version go1.21.3 linux/amd64", requires, replaces); err != nil {
+		t.Fatal(err)
+	}
+	want := `module example.com/m
+
+go 1.21.3
+
+require (
+	github.com/a/b v0.0.0-20210101000000-aaaaaaaaaaaa
+)
+
+replace (
+	github.com/a/b => ../b
+)
+`
+	if buf.String() != want {
+		t.Errorf("writeGoMod =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestWriteGoModUnparseableGoVersionOmitsDirective(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeGoMod(&buf, "example.com/m", "", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := "module example.com/m\n\nrequire (\n)\n"
+	if buf.String() != want {
+		t.Errorf("writeGoMod with blank GoVersion =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Reporter receives progress updates as Restore works through a
+// Godeps's dependencies. Implementations must be safe for concurrent
+// use, since Restore calls them from multiple goroutines.
+type Reporter interface {
+	// Start is called once, when fetching and checking out dep
+	// begins.
+	Start(dep Dependency)
+	// Done is called once for each dependency Start was called for,
+	// reporting the error (if any) from fetching and checking it out.
+	// A dependency whose work never began because ctx was cancelled
+	// gets neither call.
+	Done(dep Dependency, err error)
+}
+
+// discardReporter is the Reporter used when Restore is called
+// without one.
+type discardReporter struct{}
+
+func (discardReporter) Start(Dependency)       {}
+func (discardReporter) Done(Dependency, error) {}
+
+// Restore fetches and checks out every dependency in g, running up
+// to concurrency fetches at a time. Dependencies that share a repo
+// root (as happens when two import paths come from the same
+// repository) are fetched once, since a repeat fetch of the same
+// remote into the same RepoPath would only race with itself; those
+// that also share a Rev share one checkout, and each is still
+// verified against its own Hash.
+//
+// If ctx is cancelled, or any dependency's fetch or checkout returns
+// a fatal error, Restore stops starting new work and returns promptly
+// once the in-flight fetches and checkouts finish. Only fetches are
+// killed outright via exec.CommandContext; a checkout already running
+// when ctx is cancelled is left to complete rather than interrupted
+// mid-write, since killing it partway through could leave the
+// workdir checked out to neither the old nor the new revision.
+func (g *Godeps) Restore(ctx context.Context, concurrency int) error {
+	return g.restore(ctx, concurrency, discardReporter{})
+}
+
+// RestoreWithReporter is like Restore, but streams per-dependency
+// progress to r.
+func (g *Godeps) RestoreWithReporter(ctx context.Context, concurrency int, r Reporter) error {
+	return g.restore(ctx, concurrency, r)
+}
+
+func (g *Godeps) restore(ctx context.Context, concurrency int, r Reporter) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var fetches, checkouts singleflightGroup
+	fetchOnce := func(d *Dependency) error {
+		root := d.repoRoot.Root
+		return fetches.Do(root, func() error {
+			return fetchCtx(ctx, d, d.RemoteURL())
+		})
+	}
+	// Two Dependencies can share a WorkdirRoot (a fork pinned to the
+	// same import path and Rev via a replace, say); dedup their
+	// checkouts the same way fetchOnce dedups fetches, so they don't
+	// race each other writing to the same directory.
+	checkoutOnce := func(d *Dependency) error {
+		ran := false
+		err := checkouts.Do(d.WorkdirRoot(), func() error {
+			ran = true
+			return d.checkout()
+		})
+		if err == nil && !ran {
+			err = d.verifyHash(d.Workdir())
+		}
+		return err
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	errs := make(chan error, len(g.Deps))
+
+	for i := range g.Deps {
+		d := &g.Deps[i]
+		wg.Add(1)
+		go func(d *Dependency) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+			defer func() { <-sem }()
+
+			r.Start(*d)
+			err := fetchOnce(d)
+			if err == nil {
+				err = checkoutOnce(d)
+			}
+			r.Done(*d, err)
+			if err != nil {
+				errs <- fmt.Errorf("%s: %s", d.ImportPath, err)
+				cancel()
+			}
+		}(d)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		return err // report the first failure; cancel already stopped the rest
+	}
+	// checkout fills in any Dependency.Hash left blank by an older
+	// Godeps.json; persist that back so the next restore can verify
+	// against it instead of recomputing it forever.
+	if g.path != "" {
+		if err := g.Save(); err != nil {
+			return fmt.Errorf("saving %s: %s", g.path, err)
+		}
+	}
+	return nil
+}
+
+// fetchCtx is d.fetch, but bails out as soon as ctx is cancelled
+// instead of waiting for the fetch to finish, so a cancelled Restore
+// doesn't block on a stuck worker's VCS subprocess.
+func fetchCtx(ctx context.Context, d *Dependency, remote string) error {
+	done := make(chan error, 1)
+	go func() { done <- d.vcs.fetchContext(ctx, d.RepoPath(), remote) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetchContext is v.fetch, but runs the underlying VCS command with
+// exec.CommandContext so it is killed outright if ctx is cancelled,
+// rather than left to finish in the background.
+func (v *VCS) fetchContext(ctx context.Context, dir, remote string) error {
+	args := strings.Fields(strings.Replace(v.FetchCmd, "{remote}", remote, 1))
+	cmd := exec.CommandContext(ctx, v.vcs.Cmd, args...)
+	cmd.Dir = dir
+	return cmd.Run()
+}
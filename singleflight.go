@@ -0,0 +1,39 @@
+package main
+
+import "sync"
+
+// singleflightGroup runs fn at most once per key, regardless of how
+// many goroutines call Do for that key: the first caller actually
+// runs fn, and every other caller, concurrent or later, blocks until
+// it finishes and receives the same error, instead of racing it.
+// Results are kept for the life of the group.
+type singleflightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*singleflightCall
+}
+
+type singleflightCall struct {
+	done chan struct{}
+	err  error
+}
+
+// Do runs fn for key if no call for key has been made yet, and
+// otherwise waits for that call to finish and returns its result.
+func (g *singleflightGroup) Do(key string, fn func() error) error {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*singleflightCall)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.err
+	}
+	c := &singleflightCall{done: make(chan struct{})}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	c.err = fn()
+	close(c.done)
+	return c.err
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSingleflightGroupDedupsConcurrentCalls(t *testing.T) {
+	var group singleflightGroup
+	var calls int32
+
+	const n = 50
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	results := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			results[i] = group.Do("key", func() error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("fn ran %d times for %d concurrent callers sharing a key, want 1", got, n)
+	}
+	for i, err := range results {
+		if err != nil {
+			t.Errorf("result[%d] = %v, want nil", i, err)
+		}
+	}
+}
+
+func TestSingleflightGroupDistinctKeysRunIndependently(t *testing.T) {
+	var group singleflightGroup
+	var calls int32
+	group.Do("a", func() error { atomic.AddInt32(&calls, 1); return nil })
+	group.Do("b", func() error { atomic.AddInt32(&calls, 1); return nil })
+	if calls != 2 {
+		t.Errorf("fn ran %d times for 2 distinct keys, want 2", calls)
+	}
+}
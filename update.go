@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// cmdUpdate implements "godep update", which reports how far each dependency has fallen behind its
+// upstream branch tip.
+var cmdUpdate = &Command{
+	Run:       runUpdateCheck,
+	UsageLine: "update [-json]",
+	Short:     "report dependencies that are behind upstream",
+	Long: `
+Update fetches each dependency's remote and reports
+how many commits its checked-out Rev is behind the tip of its
+default branch, along with the revision at that tip.
+
+For github.com import paths, if GITHUB_TOKEN is set, update asks
+the GitHub API for the default branch and uses the compare-commits
+API to count commits. The remote is fetched in either case, since
+the tip revision is read from the local copy. Other remotes, and
+github.com remotes without a token, fall back to counting commits
+with the underlying VCS (git rev-list, hg log -r, etc.).
+
+The -json flag prints machine-readable output instead of the default
+human-readable table.
+`,
+}
+
+var updateJSON bool
+
+func init() {
+	cmdUpdate.Flag.BoolVar(&updateJSON, "json", false, "print JSON instead of a table")
+	commands = append(commands, cmdUpdate)
+}
+
+// UpdateStatus describes how far a Dependency has fallen behind its
+// upstream branch.
+type UpdateStatus struct {
+	ImportPath string
+	Rev        string // currently recorded revision
+	NewRev     string // tip of the upstream branch
+	Behind     int    // commits in NewRev's history but not Rev's (NewRev counted, Rev not)
+}
+
+func runUpdateCheck(cmd *Command, args []string) {
+	g, err := ReadGodeps("Godeps/Godeps.json")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	statuses, err := CheckUpdates(g)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if updateJSON {
+		if err := json.NewEncoder(os.Stdout).Encode(statuses); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
+	for _, s := range statuses {
+		if s.Behind == 0 {
+			continue
+		}
+		fmt.Printf("%s is %d commits behind (%s -> %s)\n", s.ImportPath, s.Behind, s.Rev, s.NewRev)
+	}
+}
+
+// CheckUpdates fetches each of g's dependencies and reports how far
+// behind its default branch tip it has fallen.
+func CheckUpdates(g *Godeps) ([]UpdateStatus, error) {
+	var statuses []UpdateStatus
+	for i := range g.Deps {
+		d := &g.Deps[i]
+		if err := d.fetch(d.RemoteURL()); err != nil {
+			return nil, fmt.Errorf("fetch %s: %s", d.ImportPath, err)
+		}
+		branch, err := defaultBranchFor(d)
+		if err != nil {
+			return nil, fmt.Errorf("default branch for %s: %s", d.ImportPath, err)
+		}
+		tip, err := d.vcs.tipRev(d.RepoPath(), branch)
+		if err != nil {
+			return nil, fmt.Errorf("tip rev for %s: %s", d.ImportPath, err)
+		}
+		behind, err := commitsBehind(d, tip)
+		if err != nil {
+			return nil, fmt.Errorf("commits behind for %s: %s", d.ImportPath, err)
+		}
+		statuses = append(statuses, UpdateStatus{
+			ImportPath: d.ImportPath,
+			Rev:        d.Rev,
+			NewRev:     tip,
+			Behind:     behind,
+		})
+	}
+	return statuses, nil
+}
+
+// commitsBehind counts the commits between d.Rev and newRev,
+// preferring the GitHub compare API for github.com remotes when
+// GITHUB_TOKEN is set, and otherwise asking the VCS directly.
+func commitsBehind(d *Dependency, newRev string) (int, error) {
+	if d.Rev == newRev {
+		return 0, nil
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.HasPrefix(d.repoRoot.Root, "github.com/") {
+		n, err := githubCommitsBehind(d.repoRoot.Root, d.Rev, newRev, token)
+		if err == nil {
+			return n, nil
+		}
+		log.Printf("github compare for %s failed, falling back to vcs: %s", d.ImportPath, err)
+	}
+	return d.vcs.commitsBetween(d.RepoPath(), d.Rev, newRev)
+}
+
+// githubCommitsBehind uses the GitHub compare-commits REST API to
+// count the commits between base and head in repoRoot (of the form
+// "github.com/owner/repo"), without requiring a full clone.
+func githubCommitsBehind(repoRoot, base, head, token string) (int, error) {
+	parts := strings.SplitN(repoRoot, "/", 3)
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("not an owner/repo path: %s", repoRoot)
+	}
+	owner, repo := parts[1], parts[2]
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/compare/%s...%s", owner, repo, base, head)
+	var resp struct {
+		AheadBy int `json:"ahead_by"`
+	}
+	if err := getGithubJSON(url, token, &resp); err != nil {
+		return 0, err
+	}
+	return resp.AheadBy, nil
+}
+
+// defaultBranchFor resolves the branch name d's remote treats as its
+// mainline. For github.com remotes with GITHUB_TOKEN set, it reads
+// the repo's default_branch field over the API; otherwise it asks
+// the VCS directly (e.g. the target of a git remote's HEAD symref),
+// which works the same for a renamed "main" as for an old "master".
+func defaultBranchFor(d *Dependency) (string, error) {
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.HasPrefix(d.repoRoot.Root, "github.com/") {
+		b, err := githubDefaultBranch(d.repoRoot.Root, token)
+		if err == nil {
+			return b, nil
+		}
+		log.Printf("github default branch for %s failed, falling back to vcs: %s", d.ImportPath, err)
+	}
+	return d.vcs.defaultBranch(d.RepoPath())
+}
+
+// githubDefaultBranch looks up repoRoot's default_branch over the
+// GitHub REST API, without requiring a full clone.
+func githubDefaultBranch(repoRoot, token string) (string, error) {
+	parts := strings.SplitN(repoRoot, "/", 3)
+	if len(parts) != 3 {
+		return "", fmt.Errorf("not an owner/repo path: %s", repoRoot)
+	}
+	owner, repo := parts[1], parts[2]
+	url := fmt.Sprintf("https://api.github.com/repos/%s/%s", owner, repo)
+	var resp struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	if err := getGithubJSON(url, token, &resp); err != nil {
+		return "", err
+	}
+	if resp.DefaultBranch == "" {
+		return "", fmt.Errorf("no default_branch in response")
+	}
+	return resp.DefaultBranch, nil
+}
+
+// getGithubJSON issues an authenticated GET against the GitHub REST
+// API and decodes the response body into v. The token is set as a
+// request header rather than passed to a curl subprocess, since a
+// subprocess's command-line arguments (and so its token) are visible
+// to any other local user via ps or /proc/<pid>/cmdline.
+func getGithubJSON(url, token string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "token "+token)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// defaultBranch resolves the branch name the remote checked out at
+// repo treats as its mainline (the target of a git remote's HEAD
+// symref, hg's default bookmark, and so on), rather than assuming
+// any particular literal name.
+func (v *VCS) defaultBranch(repo string) (string, error) {
+	if v.DefaultBranchCmd == "" {
+		return "trunk", nil
+	}
+	out, err := v.vcs.RunOutput(repo, v.DefaultBranchCmd)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// tipRev resolves branch to the revision at its current tip in the
+// repository checked out at repo.
+func (v *VCS) tipRev(repo, branch string) (string, error) {
+	out, err := v.vcs.RunOutput(repo, v.TipRevCmd, "branch", branch)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// commitsBetween counts the commits in to's history but not in
+// from's (so to is counted and from is not) in the repository
+// checked out at repo.
+//
+// CommitCountCmd is expected to emit one line per commit rather than
+// a literal count, since not every backend (notably hg and svn) has a
+// single command that counts revisions directly; counting lines is
+// the form every backend's log template can produce.
+func (v *VCS) commitsBetween(repo, from, to string) (int, error) {
+	out, err := v.vcs.RunOutput(repo, v.CommitCountCmd, "from", from, "to", to)
+	if err != nil {
+		return 0, err
+	}
+	trimmed := strings.TrimSpace(string(out))
+	if trimmed == "" {
+		return 0, nil
+	}
+	return len(strings.Split(trimmed, "\n")), nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGithubCommitsBehindRejectsNonGithubRoot(t *testing.T) {
+	_, err := githubCommitsBehind("example.com/foo", "a", "b", "tok")
+	if err == nil {
+		t.Fatal("expected an error for a non owner/repo root, got nil")
+	}
+}
+
+func TestGithubDefaultBranchRejectsNonGithubRoot(t *testing.T) {
+	_, err := githubDefaultBranch("example.com/foo", "tok")
+	if err == nil {
+		t.Fatal("expected an error for a non owner/repo root, got nil")
+	}
+}
+
+func TestGetGithubJSONSetsAuthorizationHeader(t *testing.T) {
+	var gotAuth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"ahead_by": 3}`))
+	}))
+	defer srv.Close()
+
+	var resp struct {
+		AheadBy int `json:"ahead_by"`
+	}
+	if err := getGithubJSON(srv.URL, "secret-tok", &resp); err != nil {
+		t.Fatal(err)
+	}
+	// The token must travel as a header, never as a curl argv entry:
+	// argv is visible to any other local user via ps or
+	// /proc/<pid>/cmdline.
+	if want := "token secret-tok"; gotAuth != want {
+		t.Errorf("Authorization header = %q, want %q", gotAuth, want)
+	}
+	if resp.AheadBy != 3 {
+		t.Errorf("AheadBy = %d, want 3", resp.AheadBy)
+	}
+}
+
+func TestGetGithubJSONNonOKStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+	var resp struct{}
+	if err := getGithubJSON(srv.URL, "tok", &resp); err == nil {
+		t.Fatal("expected an error for a non-200 response, got nil")
+	}
+}
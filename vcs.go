@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strings"
+
+	"code.google.com/p/go.tools/go/vcs"
+)
+
+// A VCS drives a single version control tool (git, hg, svn, and
+// whatever else gets registered with RegisterVCS) by substituting
+// {placeholder} keys into its command templates and running the
+// result with vcs.RunOutput. Only the command fields a given backend
+// actually needs have to be set; the rest are left as their zero
+// value and the corresponding method reports that accordingly (link,
+// for instance, is a no-op when LinkCmd is empty).
+type VCS struct {
+	vcs *vcs.Cmd
+
+	IdentifyCmd string
+	IsDirtyCmd  string
+	DescribeCmd string
+	CreateCmd   string
+	LinkCmd     string
+	FetchCmd    string
+	ExistsCmd   string
+	CheckoutCmd string
+
+	TipRevCmd        string
+	CommitTimeCmd    string
+	CommitCountCmd   string
+	DefaultBranchCmd string
+}
+
+// identify, isDirty, describe, create, link, fetch, exists, and
+// checkout are implemented generically in terms of a VCS's command
+// templates, so that adding a backend (as vcsBzr and vcsFossil in
+// vcs_registry.go do) means filling in a struct literal rather than
+// writing a parallel set of methods.
+
+func (v *VCS) identify(dir string) (string, error) {
+	out, err := v.vcs.RunOutput(dir, v.IdentifyCmd)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (v *VCS) isDirty(dir string) bool {
+	out, err := v.vcs.RunOutput(dir, v.IsDirtyCmd)
+	if err != nil {
+		return true // can't tell; assume the worst
+	}
+	return len(strings.TrimSpace(string(out))) > 0
+}
+
+func (v *VCS) describe(dir, rev string) string {
+	out, err := v.vcs.RunOutput(dir, v.DescribeCmd, "rev", rev)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func (v *VCS) create(dir string) error {
+	_, err := v.vcs.RunOutput(dir, v.CreateCmd)
+	return err
+}
+
+func (v *VCS) link(dir, remote, url string) error {
+	if v.LinkCmd == "" {
+		return nil // e.g. svn has no concept of a named remote
+	}
+	_, err := v.vcs.RunOutput(dir, v.LinkCmd, "remote", remote, "url", url)
+	return err
+}
+
+func (v *VCS) fetch(dir, remote string) error {
+	_, err := v.vcs.RunOutput(dir, v.FetchCmd, "remote", remote)
+	return err
+}
+
+func (v *VCS) exists(dir, rev string) bool {
+	_, err := v.vcs.RunOutput(dir, v.ExistsCmd, "rev", rev)
+	return err == nil
+}
+
+func (v *VCS) checkout(dir, rev, repo string) error {
+	_, err := v.vcs.RunOutput(repo, v.CheckoutCmd, "dir", dir, "rev", rev, "repo", repo)
+	return err
+}
+
+// vcsGit drives Git.
+var vcsGit = &VCS{
+	vcs: vcs.ByCmd("git"),
+
+	IdentifyCmd: "rev-parse HEAD",
+	IsDirtyCmd:  "status --porcelain",
+	DescribeCmd: "describe --tags {rev}",
+	CreateCmd:   "init",
+	LinkCmd:     "remote add {remote} {url}",
+	FetchCmd:    "fetch {remote}",
+	ExistsCmd:   "cat-file -e {rev}",
+	CheckoutCmd: "worktree add {dir} {rev}",
+
+	TipRevCmd:        "rev-parse {branch}",
+	CommitTimeCmd:    "log -1 --format=%cI {rev}",
+	CommitCountCmd:   "rev-list {from}..{to}",
+	DefaultBranchCmd: "symbolic-ref --short refs/remotes/origin/HEAD",
+}
+
+// vcsHg drives Mercurial.
+var vcsHg = &VCS{
+	vcs: vcs.ByCmd("hg"),
+
+	IdentifyCmd: "parent --template {node}",
+	IsDirtyCmd:  "status",
+	DescribeCmd: "log -r {rev} --template {desc}",
+	CreateCmd:   "init",
+	LinkCmd:     "paths -q {remote}",
+	FetchCmd:    "pull {remote}",
+	ExistsCmd:   "log -r {rev}",
+	CheckoutCmd: "archive -r {rev} {dir}",
+
+	TipRevCmd:        "log -r {branch} --template {node}",
+	CommitTimeCmd:    "log -r {rev} --template {date|rfc3339date}",
+	CommitCountCmd:   "log -r (::{to})-(::{from}) --template {node}\\n",
+	DefaultBranchCmd: "log -r default --template {branch}",
+}
+
+// vcsSvn drives Subversion. Subversion has no local clone or named
+// remote the way the other backends do -- every command talks
+// straight to RemoteURL -- and no first-class notion of a default
+// branch, so DefaultBranchCmd is left blank and defaultBranch falls
+// back to the "trunk" convention every svn repo that has branches at
+// all is expected to follow.
+var vcsSvn = &VCS{
+	vcs: vcs.ByCmd("svn"),
+
+	IdentifyCmd: "info --show-item revision",
+	IsDirtyCmd:  "status",
+	DescribeCmd: "log -r {rev} -q",
+	CreateCmd:   "",
+	LinkCmd:     "",
+	FetchCmd:    "update",
+	ExistsCmd:   "info -r {rev}",
+	CheckoutCmd: "export -r {rev} {repo} {dir}",
+
+	TipRevCmd:        "info -r HEAD --show-item revision",
+	CommitTimeCmd:    "info -r {rev} --show-item last-changed-date",
+	CommitCountCmd:   "log -q -r {from}:{to}",
+	DefaultBranchCmd: "",
+}
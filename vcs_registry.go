@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+
+	"code.google.com/p/go.tools/go/vcs"
+)
+
+// registry holds the VCS backends godep knows how to use, keyed by
+// the name reported in a repoRoot's VCS.Cmd (e.g. "git", "hg",
+// "bzr", "fossil"). VCSForImportPath resolves against it, so a
+// backend can be added here, by a third party, without patching
+// VCSForImportPath itself.
+var registry = make(map[string]*VCS)
+
+// RegisterVCS adds a VCS backend under name so that VCSForImportPath
+// can resolve import paths hosted under it. Built-ins (git, hg, svn,
+// bzr and fossil) register themselves from init; third parties
+// wanting to support a tool godep doesn't ship add it to registry
+// from their plugin's init by calling RegisterVCS.
+func RegisterVCS(name string, v *VCS) {
+	registry[name] = v
+}
+
+func init() {
+	RegisterVCS("git", vcsGit)
+	RegisterVCS("hg", vcsHg)
+	RegisterVCS("svn", vcsSvn)
+	RegisterVCS("bzr", vcsBzr)
+	RegisterVCS("fossil", vcsFossil)
+}
+
+// VCSForImportPath resolves importPath to the repository root vcs
+// knows how to reach it at, and the VCS backend registered to drive
+// that repository's tool. It returns an error for any importPath
+// whose VCS isn't in registry, rather than silently mishandling it.
+func VCSForImportPath(importPath string) (*VCS, *vcs.RepoRoot, error) {
+	repoRoot, err := vcs.RepoRootForImportPath(importPath, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	v, ok := registry[repoRoot.VCS.Cmd]
+	if !ok {
+		return nil, nil, fmt.Errorf("%s is unsupported", repoRoot.VCS.Cmd)
+	}
+	return v, repoRoot, nil
+}
+
+// vcsBzr drives Bazaar. golang.org/x/tools/go/vcs already recognizes
+// bzr import paths (launchpad.net/...); only the godep-level command
+// set was missing.
+var vcsBzr = &VCS{
+	vcs: vcs.ByCmd("bzr"),
+
+	IdentifyCmd:      "log -r-1 --template={revision_id}",
+	IsDirtyCmd:       "status",
+	DescribeCmd:      "log -r{rev} --template={revision_id} ({tags})",
+	CreateCmd:        "init",
+	FetchCmd:         "pull {remote}",
+	ExistsCmd:        "log -r{rev}",
+	CheckoutCmd:      "export --revision={rev} {dir}",
+	TipRevCmd:        "log -r{branch} --template={revision_id}",
+	CommitTimeCmd:    "log -r{rev} --template={date}",
+	CommitCountCmd:   "log -r{from}..{to} --template=.",
+	DefaultBranchCmd: "config parent_branch",
+}
+
+// vcsFossil drives Fossil. Unlike bzr, x/tools/go/vcs has no notion
+// of Fossil import paths at all (it isn't one of the hosting
+// conventions it pattern-matches), so a Fossil checkout can only be
+// reached today via its raw repo URL, same as the "custom import
+// path" escape hatch the go tool itself offers for unlisted hosts.
+var vcsFossil = &VCS{
+	vcs: &vcs.Cmd{
+		Name:   "Fossil",
+		Cmd:    "fossil",
+		Scheme: []string{"https", "http"},
+	},
+
+	IdentifyCmd:      "info | awk '/^checkout:/ {print $2}'",
+	IsDirtyCmd:       "changes",
+	DescribeCmd:      "info {rev} | awk '/^comment:/ {$1=\"\"; print}'",
+	CreateCmd:        "init {dir}.fossil",
+	FetchCmd:         "pull {remote}",
+	ExistsCmd:        "info {rev}",
+	CheckoutCmd:      "open {repo} {rev}",
+	TipRevCmd:        "info {branch} | awk '/^checkout:/ {print $2}'",
+	CommitTimeCmd:    "info {rev} | awk '/^uuid:/ {print $4, $5}'",
+	CommitCountCmd:   "timeline -type ci {from}..{to} | grep -c '^[0-9]'",
+	DefaultBranchCmd: "info | awk '/^tags:/ {print $2}'",
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestRegisterVCSAddsToRegistry(t *testing.T) {
+	v := &VCS{}
+	RegisterVCS("test-vcs", v)
+	defer delete(registry, "test-vcs")
+
+	got, ok := registry["test-vcs"]
+	if !ok {
+		t.Fatal("RegisterVCS did not add the backend to registry")
+	}
+	if got != v {
+		t.Errorf("registry[%q] = %v, want %v", "test-vcs", got, v)
+	}
+}
+
+func TestRegisterVCSOverwritesExisting(t *testing.T) {
+	v1, v2 := &VCS{}, &VCS{}
+	RegisterVCS("test-vcs-2", v1)
+	RegisterVCS("test-vcs-2", v2)
+	defer delete(registry, "test-vcs-2")
+
+	if registry["test-vcs-2"] != v2 {
+		t.Error("a later RegisterVCS call for the same name should replace the earlier one")
+	}
+}
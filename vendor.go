@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// cmdVendor implements "godep vendor", which materializes each
+// dependency's checked-out tree into <project>/vendor/<ImportPath>/,
+// as an alternative to rewriting GOPATH under spool. This lets
+// projects build with go build -mod=vendor without abandoning their
+// existing Godeps.json.
+var cmdVendor = &Command{
+	Run:       runVendor,
+	UsageLine: "vendor",
+	Short:     "copy dependencies into ./vendor",
+	Long: `
+Vendor reads Godeps.json and copies each dependency's checked-out
+tree into vendor/<ImportPath> under the current project, stripping
+nested vendor directories and VCS metadata. It also writes
+vendor/modules.txt in the same format the go tool itself writes
+and checks against for go build -mod=vendor: a "# module version"
+header per repository root (coalesced the same way "godep mod"
+coalesces its require lines), an "## explicit" marker, and the
+vendored package import paths under it.
+`,
+}
+
+func init() {
+	commands = append(commands, cmdVendor)
+}
+
+func runVendor(cmd *Command, args []string) {
+	g, err := ReadGodeps("Godeps/Godeps.json")
+	if err != nil {
+		log.Fatalln(err)
+	}
+	if err := g.VendorTo("vendor"); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// VendorTo materializes g's dependencies into dir, in the layout the
+// go tool expects for -mod=vendor: dir/<ImportPath>/... for each
+// dependency, plus a dir/modules.txt manifest.
+func (g *Godeps) VendorTo(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	for _, d := range g.Deps {
+		dst := filepath.Join(dir, d.ImportPath)
+		if err := copyTree(d.Workdir(), dst); err != nil {
+			return fmt.Errorf("vendoring %s: %s", d.ImportPath, err)
+		}
+	}
+	requires, err := coalesceRequires(g.Deps)
+	if err != nil {
+		return fmt.Errorf("vendor manifest: %s", err)
+	}
+	f, err := os.Create(filepath.Join(dir, "modules.txt"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return writeVendorManifest(f, requires, g.Deps)
+}
+
+// copyTree copies src to dst, skipping nested vendor directories and
+// VCS metadata so the vendored copy contains only the package's own
+// code.
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if rel != "." && (info.Name() == "vendor" || vcsMetaDirs[info.Name()]) {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(dst, rel), 0777)
+		}
+		if vcsMetaDirs[info.Name()] {
+			return nil // e.g. the .git file of a git worktree
+		}
+		return copyFile(path, filepath.Join(dst, rel))
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// writeVendorManifest writes a vendor/modules.txt in the schema the
+// go tool parses and validates for -mod=vendor: for each module
+// (one per requires entry, as coalesced by coalesceRequires),
+//
+//	# <module path> <version>
+//	## explicit
+//	<package import path>
+//	...
+//
+// with packages listed under their module in sorted order, and
+// modules themselves already sorted by coalesceRequires.
+func writeVendorManifest(w io.Writer, requires []modRequire, deps []Dependency) error {
+	pkgs := make(map[string][]string)
+	for _, d := range deps {
+		root := d.repoRoot.Root
+		pkgs[root] = append(pkgs[root], d.ImportPath)
+	}
+	for _, r := range requires {
+		if _, err := fmt.Fprintf(w, "# %s %s\n", r.root, r.version); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "## explicit\n"); err != nil {
+			return err
+		}
+		ps := pkgs[r.root]
+		sort.Strings(ps)
+		for _, p := range ps {
+			if _, err := fmt.Fprintf(w, "%s\n", p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"code.google.com/p/go.tools/go/vcs"
+)
+
+func TestWriteVendorManifest(t *testing.T) {
+	requires := []modRequire{
+		{root: "github.com/a/b", version: "v0.0.0-20210101000000-aaaaaaaaaaaa"},
+		{root: "github.com/c/d", version: "v0.0.0-20210102000000-bbbbbbbbbbbb"},
+	}
+	deps := []Dependency{
+		{ImportPath: "github.com/a/b/sub"},
+		{ImportPath: "github.com/a/b"},
+		{ImportPath: "github.com/c/d"},
+	}
+	// writeVendorManifest groups deps by repo root via d.repoRoot,
+	// which only ReadGodeps populates; set it directly here.
+	for i := range deps {
+		switch deps[i].ImportPath {
+		case "github.com/a/b/sub", "github.com/a/b":
+			deps[i].repoRoot = &vcs.RepoRoot{Root: "github.com/a/b"}
+		case "github.com/c/d":
+			deps[i].repoRoot = &vcs.RepoRoot{Root: "github.com/c/d"}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := writeVendorManifest(&buf, requires, deps); err != nil {
+		t.Fatal(err)
+	}
+	want := `# github.com/a/b v0.0.0-20210101000000-aaaaaaaaaaaa
+## explicit
+github.com/a/b
+github.com/a/b/sub
+# github.com/c/d v0.0.0-20210102000000-bbbbbbbbbbbb
+## explicit
+github.com/c/d
+`
+	if buf.String() != want {
+		t.Errorf("writeVendorManifest =\n%s\nwant\n%s", buf.String(), want)
+	}
+}